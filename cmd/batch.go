@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lem-in/utils"
+)
+
+// result holds the numbers reported for one map in batch mode.
+type result struct {
+	ants, rooms, paths, turns int
+	took                      time.Duration
+}
+
+// runBatch solves every *.txt (or *.txt.gz) map in dir with opts and
+// prints a table to w. With quiet only the rows of failed maps are printed.
+// A bad map is reported and skipped. When outDir is set the usual output
+// of each map is written to outDir/<name>.out. It returns how many maps failed.
+func runBatch(dir, outDir string, opts solveOptions, quiet bool, w io.Writer) int {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		if err == nil {
+			err = fmt.Errorf("%s is not a directory", dir)
+		}
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	zipped, _ := filepath.Glob(filepath.Join(dir, "*.txt.gz"))
+	files = append(files, zipped...)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if !quiet {
+		fmt.Fprintln(tw, "FILE\tANTS\tROOMS\tPATHS\tTURNS\tTIME")
+	}
+	failed := 0
+	for _, f := range files {
+		name := filepath.Base(f)
+		res, out, err := solveFile(f, opts)
+		if err == nil && outDir != "" {
+			err = os.WriteFile(filepath.Join(outDir, strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".txt")+".out"), []byte(out), 0o644)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", name, err)
+			continue
+		}
+		if quiet {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", name, res.ants, res.rooms, res.paths, res.turns, res.took)
+	}
+	tw.Flush()
+	return failed
+}
+
+// solveFile parses and solves one map, returning its stats and the text
+// lem-in would print for it.
+func solveFile(path string, opts solveOptions) (result, string, error) {
+	begin := time.Now()
+	graph, lines, err := utils.ParseInputWith(path, opts.parse)
+	if err != nil {
+		return result{}, "", err
+	}
+	paths, _, err := solve(graph, opts)
+	if err != nil {
+		return result{}, "", err
+	}
+	var out bytes.Buffer
+	turns, err := writeOutput(&out, graph, lines, paths, opts.out)
+	if err != nil {
+		return result{}, "", err
+	}
+	res := result{
+		ants:  graph.Ants,
+		rooms: len(graph.Rooms),
+		paths: len(paths),
+		turns: turns,
+		took:  time.Since(begin),
+	}
+	return res, out.String(), nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"lem-in/utils"
+)
+
+func main() {
+	rooms := flag.Int("rooms", 20, "number of rooms, start and end included")
+	edges := flag.Int("edges", 10, "number of random links added on top of the connecting tree")
+	ants := flag.Int("ants", 10, "number of ants")
+	seed := flag.Int64("seed", 1, "random seed; the same seed gives the same map")
+	flag.Parse()
+
+	fmt.Print(utils.GenerateMap(*rooms, *edges, *ants, *seed))
+}
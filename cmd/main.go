@@ -1,29 +1,237 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"lem-in/utils"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: lem-in <file>")
-		os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run is the whole program: it reads the flags in args, solves the map and
+// writes the result to stdout and diagnostics to stderr. It returns the
+// exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lem-in", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	batch := fs.String("batch", "", "solve every *.txt map in `dir` and print a summary to stderr")
+	batchOut := fs.String("batch-out", "", "with -batch, write the output for each map into `dir`, as the output flags shape it")
+	showPaths := fs.Bool("showpaths", false, "print the chosen paths to stderr, one per line")
+	prune := fs.Bool("prune", false, "drop rooms no path can use before solving and report how many")
+	quiet := fs.Bool("quiet", false, "do not print the -prune report or the -batch rows of solved maps to stderr")
+	jsonl := fs.Bool("jsonl", false, "print only the moves, one JSON array per turn")
+	noEcho := fs.Bool("noecho", false, "print only the moves, not the map")
+	maxLen := fs.String("maxlen", "", "ignore paths longer than `n` turns, or n turns more than the shortest when written +n; a smaller search that may cost turns")
+	dotFile := fs.String("dot", "", "also write the map and chosen paths as Graphviz DOT to `file`")
+	pathInfo := fs.Bool("pathinfo", false, "list each chosen path and its ants as '# path' and '# ants' comment lines after the map")
+	jsonErrors := fs.Bool("json-errors", false, "print errors as JSON objects with line and column")
+	debug := fs.Bool("debug", false, "print why a map was rejected to stderr")
+	maxRooms := fs.Int("max-rooms", utils.MaxRooms, "reject maps with more rooms than this")
+	maxLinks := fs.Int("max-links", utils.MaxLinks, "reject maps with more links than this")
+	weighted := fs.Bool("weighted", false, "read links written a-b:n as tunnels taking n turns")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	// stats gets the reports -quiet turns off
+	stats := stderr
+	if *quiet {
+		stats = io.Discard
+	}
+	// reasons gets the detail behind an error, shown only with -debug
+	reasons := io.Discard
+	if *debug {
+		reasons = stderr
+	}
+
+	limit, err := parseMaxLen(*maxLen)
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return 1
+	}
+	if *maxRooms <= 0 {
+		fmt.Fprintf(stdout, "invalid -max-rooms %d\n", *maxRooms)
+		return 1
+	}
+	if *maxLinks <= 0 {
+		fmt.Fprintf(stdout, "invalid -max-links %d\n", *maxLinks)
+		return 1
+	}
+	opts := solveOptions{
+		parse: utils.ParseOptions{
+			Limits:   utils.Limits{Rooms: *maxRooms, Links: *maxLinks},
+			Weighted: *weighted,
+		},
+		limit: limit,
+		prune: *prune,
+		out:   outputOptions{noEcho: *noEcho, jsonl: *jsonl, pathInfo: *pathInfo},
+	}
+
+	if *batch != "" {
+		if runBatch(*batch, *batchOut, opts, *quiet, stderr) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stdout, "Usage: lem-in <file>")
+		return 1
+	}
+	graph, lines, err := utils.ParseInputWith(fs.Arg(0), opts.parse)
+	if err != nil {
+		return fail(stdout, reasons, err, *jsonErrors)
+	}
+	paths, pruned, err := solve(graph, opts)
+	if err != nil {
+		return fail(stdout, reasons, err, *jsonErrors)
+	}
+	if *prune {
+		fmt.Fprintf(stats, "pruned %d rooms\n", pruned)
 	}
-	graph, lines := utils.ParseInput(os.Args[1])
-	paths := utils.FindPaths(graph)
+	if *dotFile != "" {
+		if err := os.WriteFile(*dotFile, []byte(utils.ToDOT(graph, paths)), 0o644); err != nil {
+			return fail(stdout, reasons, err, *jsonErrors)
+		}
+	}
+	if *showPaths {
+		for _, p := range paths {
+			fmt.Fprintln(stderr, strings.Join(pathNames(p), " "))
+		}
+	}
+
+	if _, err := writeOutput(stdout, graph, lines, paths, opts.out); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// solveOptions are the flags that change how a map is solved and printed.
+type solveOptions struct {
+	parse utils.ParseOptions
+	limit utils.PathLimit
+	prune bool
+	out   outputOptions
+}
+
+// outputOptions are the flags that change what is printed for a solved map.
+type outputOptions struct {
+	noEcho   bool // leave out the map
+	jsonl    bool // only the moves, as JSON arrays
+	pathInfo bool // list the paths and their ants as comments
+}
+
+// writeOutput writes what lem-in prints for a solved map to w: the map
+// lines as read, the optional path comments, and the moves. It returns how
+// many turns the ants took.
+func writeOutput(w io.Writer, graph *utils.Graph, lines []string, paths [][]*utils.Room, opts outputOptions) (int, error) {
+	// Large solutions are many lines; write them in blocks rather than
+	// one system call per line
+	out := bufio.NewWriter(w)
+	if !opts.noEcho && !opts.jsonl {
+		// lines are printed exactly as read, only the line endings may differ
+		for _, l := range lines {
+			fmt.Fprintln(out, l)
+		}
+	}
+	if opts.pathInfo && !opts.jsonl {
+		// comment lines, so anything reading the map still accepts it
+		for i, ants := range utils.AntsByPath(paths, graph.Ants) {
+			nums := make([]string, len(ants))
+			for j, a := range ants {
+				nums[j] = strconv.Itoa(a)
+			}
+			fmt.Fprintf(out, "# path %d: %s\n", i, strings.Join(pathNames(paths[i]), " "))
+			fmt.Fprintf(out, "# ants %d: %s\n", i, strings.Join(nums, " "))
+		}
+	}
+	// a blank line separates the header from the moves
+	if (!opts.noEcho || opts.pathInfo) && !opts.jsonl {
+		fmt.Fprintln(out)
+	}
+	moves, turns := utils.SimulateMulti(graph, paths)
+	for _, m := range moves {
+		if opts.jsonl {
+			b, _ := json.Marshal(append([]string{}, strings.Fields(m)...))
+			fmt.Fprintln(out, string(b))
+			continue
+		}
+		fmt.Fprintln(out, m)
+	}
+	return turns, out.Flush()
+}
+
+// solve picks the paths for graph, first pruning it when asked to. It also
+// returns how many rooms were pruned.
+func solve(graph *utils.Graph, opts solveOptions) ([][]*utils.Room, int, error) {
+	pruned := 0
+	if opts.prune {
+		n, err := graph.Prune()
+		if err != nil {
+			return nil, 0, err
+		}
+		pruned = n
+	}
+	paths := utils.FindPathsWithin(graph, opts.limit)
 	if len(paths) == 0 {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: no path from start to end")
-		os.Exit(1)
+		return nil, 0, utils.NoPathError()
+	}
+	return paths, pruned, nil
+}
+
+// fail reports an error and returns the exit code for it. For a rejected
+// map w only gets "ERROR: invalid data format", as the lem-in spec asks,
+// and the reason goes to reasons. Any other error, such as a file that
+// cannot be read or written, is printed to w as it is. With asJSON the
+// error is instead printed to w as a ParseError object on one line.
+func fail(w, reasons io.Writer, err error, asJSON bool) int {
+	pe, ok := err.(*utils.ParseError)
+	if asJSON {
+		if !ok {
+			pe = &utils.ParseError{Kind: "io", Message: err.Error()}
+		}
+		b, _ := json.Marshal(pe)
+		fmt.Fprintln(w, string(b))
+		return 1
+	}
+	if !ok {
+		fmt.Fprintln(w, err)
+		return 1
 	}
-	for _, l := range lines {
-		fmt.Println(l)
+	fmt.Fprintln(w, "ERROR: invalid data format")
+	fmt.Fprintln(reasons, pe)
+	return 1
+}
+
+// parseMaxLen reads the -maxlen value: "n" for an absolute limit,
+// "+n" for a limit relative to the shortest path, "" for none.
+func parseMaxLen(s string) (utils.PathLimit, error) {
+	if s == "" {
+		return utils.PathLimit{}, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "+"))
+	relative := strings.HasPrefix(s, "+")
+	if err != nil || n < 0 || (n == 0 && !relative) {
+		return utils.PathLimit{}, fmt.Errorf("invalid -maxlen %q", s)
 	}
-	fmt.Println()
-	for _, m := range utils.SimulateMulti(graph, paths) {
-		fmt.Println(m)
+	return utils.PathLimit{Max: n, Relative: relative}, nil
+}
+
+// pathNames lists the room names along a path.
+func pathNames(p []*utils.Room) []string {
+	names := make([]string, len(p))
+	for i, r := range p {
+		names[i] = r.Name
 	}
+	return names
 }
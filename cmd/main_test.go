@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lem-in/utils"
+)
+
+// runCmd runs lem-in with args and returns its exit code, stdout and stderr.
+func runCmd(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// newlines turns any line ending into "\n".
+var newlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func TestEchoMatchesInput(t *testing.T) {
+	for _, file := range []string{"example00.txt", "example01.txt", "example00_crlf.txt", "example00_cr.txt"} {
+		path := "../examples/" + file
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, out, _ := runCmd(path)
+		if code != 0 {
+			t.Fatalf("%s: exit code %d", file, code)
+		}
+		header, _, found := strings.Cut(out, "\n\n")
+		if !found {
+			t.Fatalf("%s: no blank line after the map", file)
+		}
+		want := strings.TrimRight(newlines.Replace(string(data)), "\n")
+		if header != want {
+			t.Errorf("%s: echoed map differs from the input\ngot:\n%s\nwant:\n%s", file, header, want)
+		}
+	}
+}
+
+func TestJSONLMatchesText(t *testing.T) {
+	path := "../examples/example01.txt"
+	_, text, _ := runCmd("-noecho", path)
+	code, out, _ := runCmd("-jsonl", path)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	turns := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(turns) {
+		t.Fatalf("got %d JSON lines, want %d", len(lines), len(turns))
+	}
+	for i, l := range lines {
+		var moves []string
+		if err := json.Unmarshal([]byte(l), &moves); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if got := strings.Join(moves, " "); got != turns[i] {
+			t.Errorf("line %d: got %q, want %q", i+1, got, turns[i])
+		}
+	}
+}
+
+func TestParseMaxLen(t *testing.T) {
+	tests := []struct {
+		in   string
+		want utils.PathLimit
+		ok   bool
+	}{
+		{"", utils.PathLimit{}, true},
+		{"5", utils.PathLimit{Max: 5}, true},
+		{"+0", utils.PathLimit{Relative: true}, true},
+		{"+2", utils.PathLimit{Max: 2, Relative: true}, true},
+		{"0", utils.PathLimit{}, false},
+		{"-1", utils.PathLimit{}, false},
+		{"+-1", utils.PathLimit{}, false},
+		{"x", utils.PathLimit{}, false},
+	}
+	for _, tc := range tests {
+		got, err := parseMaxLen(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("%q: got %+v, %v; want %+v, ok %v", tc.in, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+// TestPathInfoRoundTrip checks the map printed with -pathinfo can be read
+// back as the same map.
+func TestPathInfoRoundTrip(t *testing.T) {
+	for _, file := range []string{"example01.txt", "example05.txt"} {
+		path := "../examples/" + file
+		_, out, _ := runCmd("-pathinfo", path)
+		header, _, _ := strings.Cut(out, "\n\n")
+		if !strings.Contains(header, "# path 0: ") {
+			t.Fatalf("%s: no path lines in\n%s", file, header)
+		}
+		g, _, err := utils.ParseReader(strings.NewReader(header))
+		if err != nil {
+			t.Fatalf("%s: printed map rejected: %v", file, err)
+		}
+		want, _, _ := utils.ParseInput(path)
+		if g.Ants != want.Ants || len(g.Rooms) != len(want.Rooms) {
+			t.Errorf("%s: got %d ants, %d rooms; want %d, %d", file, g.Ants, len(g.Rooms), want.Ants, len(want.Rooms))
+		}
+	}
+}
+
+func TestPathInfoNoEcho(t *testing.T) {
+	_, out, _ := runCmd("-pathinfo", "-noecho", "../examples/example00.txt")
+	want := "# path 0: 0 2 3 1\n# ants 0: 1 2 3 4\n\nL1-2\n"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("got\n%s\nwant it to start with\n%s", out, want)
+	}
+}
+
+// batchDir makes a directory holding one good and one bad map.
+func batchDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	good, err := os.ReadFile("../examples/example00.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "good.txt"), good, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("1\n##start\ns 0 0\n##end\ne 1 1\ns-s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestBatch(t *testing.T) {
+	dir := batchDir(t)
+	code, _, errs := runCmd("-batch", dir)
+	if code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	for _, want := range []string{"FILE", "good.txt", "bad.txt", "line 6, column 1: self-loop link s-s"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("table has no %q:\n%s", want, errs)
+		}
+	}
+}
+
+func TestBatchQuietKeepsFailures(t *testing.T) {
+	dir := batchDir(t)
+	code, out, errs := runCmd("-quiet", "-batch", dir)
+	if code != 1 || out != "" {
+		t.Errorf("got exit code %d, stdout %q; want 1 and nothing", code, out)
+	}
+	if !strings.Contains(errs, "bad.txt") {
+		t.Errorf("failed map missing from:\n%s", errs)
+	}
+	if strings.Contains(errs, "good.txt") || strings.Contains(errs, "FILE") {
+		t.Errorf("-quiet still printed solved maps:\n%s", errs)
+	}
+}
+
+func TestBatchMissingDir(t *testing.T) {
+	if code, _, errs := runCmd("-batch", filepath.Join(t.TempDir(), "none")); code != 1 || errs == "" {
+		t.Errorf("got exit code %d, stderr %q; want 1 and an error", code, errs)
+	}
+}
+
+const validHead = "1\n##start\ns 0 0\n##end\ne 1 1\n"
+
+// invalidMaps has one rejected map of each ParseError kind.
+var invalidMaps = []struct {
+	kind         string
+	text         string
+	line, column int
+	reason       string
+}{
+	{"ants", "x\n##start\ns 0 0\n##end\ne 1 1\ns-e\n", 1, 1, "line 1, column 1: invalid ants count"},
+	{"limit", "60000\n##start\ns 0 0\n##end\ne 1 1\ns-e\n", 1, 1, "line 1, column 1: ant count is more than 50000"},
+	{"command", "1\n##start\n##start\ns 0 0\n##end\ne 1 1\ns-e\n", 3, 1, "line 3, column 1: duplicate start"},
+	{"room", "1\n##start\ns 0 0\n##end\ne 0 0\ns-e\n", 5, 3, "line 5, column 3: duplicate coordinates 0 0"},
+	{"link", validHead + "s-s\n", 6, 1, "line 6, column 1: self-loop link s-s"},
+	{"line", validHead + "foo\n", 6, 1, "line 6, column 1: invalid line"},
+	{"map", "1\ns 0 0\n##end\ne 1 1\ns-e\n", 0, 0, "missing start or end"},
+	{"path", validHead, 0, 0, "no path from start to end"},
+}
+
+// writeMap saves text as a map file and returns its path.
+func writeMap(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestInvalidMapOutput checks that every kind of rejected map prints only
+// the spec error to stdout, and its reason to stderr with -debug.
+func TestInvalidMapOutput(t *testing.T) {
+	for _, tc := range invalidMaps {
+		path := writeMap(t, tc.text)
+		code, out, errs := runCmd(path)
+		if code != 1 || out != "ERROR: invalid data format\n" || errs != "" {
+			t.Errorf("%s: got exit code %d, stdout %q, stderr %q", tc.kind, code, out, errs)
+		}
+		_, out, errs = runCmd("-debug", path)
+		if out != "ERROR: invalid data format\n" || errs != tc.reason+"\n" {
+			t.Errorf("%s with -debug: got stdout %q, stderr %q; want reason %q", tc.kind, out, errs, tc.reason)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	decode := func(t *testing.T, out string) utils.ParseError {
+		t.Helper()
+		var pe utils.ParseError
+		if strings.Count(out, "\n") != 1 {
+			t.Fatalf("got %q, want one JSON line", out)
+		}
+		if err := json.Unmarshal([]byte(out), &pe); err != nil {
+			t.Fatalf("%q: %v", out, err)
+		}
+		return pe
+	}
+	for _, tc := range invalidMaps {
+		code, out, _ := runCmd("-json-errors", writeMap(t, tc.text))
+		pe := decode(t, out)
+		if code != 1 || pe.Kind != tc.kind || pe.Line != tc.line || pe.Column != tc.column {
+			t.Errorf("%s: got exit code %d, %+v; want kind %s at %d:%d", tc.kind, code, pe, tc.kind, tc.line, tc.column)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "none.txt")
+	_, out, _ := runCmd("-json-errors", missing)
+	want := utils.ParseError{Kind: "io", Message: "open " + missing + ": no such file or directory"}
+	if pe := decode(t, out); pe != want {
+		t.Errorf("missing file: got %+v, want %+v", pe, want)
+	}
+}
+
+func TestIOErrorOutput(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "none.txt")
+	code, out, _ := runCmd(missing)
+	if want := "open " + missing + ": no such file or directory\n"; code != 1 || out != want {
+		t.Errorf("missing file: got exit code %d, stdout %q; want 1, %q", code, out, want)
+	}
+	dot := filepath.Join(t.TempDir(), "none", "map.dot")
+	code, out, _ = runCmd("-dot", dot, "../examples/example00.txt")
+	if want := "open " + dot + ": no such file or directory\n"; code != 1 || out != want {
+		t.Errorf("unwritable -dot: got exit code %d, stdout %q; want 1, %q", code, out, want)
+	}
+}
+
+// TestBatchOutMatchesRun checks each -batch-out file holds exactly what a
+// normal run prints for that map, with the same output flags.
+func TestBatchOutMatchesRun(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"example00.txt", "noants.txt"} {
+		data, err := os.ReadFile(filepath.Join("..", "examples", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, flags := range [][]string{nil, {"-noecho", "-pathinfo"}, {"-jsonl"}} {
+		outDir := t.TempDir()
+		args := append([]string{"-quiet", "-batch", dir, "-batch-out", outDir}, flags...)
+		if code, _, errs := runCmd(args...); code != 0 {
+			t.Fatalf("%v: exit code %d: %s", flags, code, errs)
+		}
+		for _, name := range []string{"example00", "noants"} {
+			got, err := os.ReadFile(filepath.Join(outDir, name+".out"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, want, _ := runCmd(append(flags, filepath.Join(dir, name+".txt"))...)
+			if string(got) != want {
+				t.Errorf("%s %v: batch wrote\n%q\nrun printed\n%q", name, flags, got, want)
+			}
+		}
+	}
+}
+
+func TestSizeCapFlags(t *testing.T) {
+	for _, args := range [][]string{{"-max-rooms", "0"}, {"-max-rooms", "-1"}, {"-max-links", "0"}, {"-max-links", "-5"}} {
+		code, out, _ := runCmd(append(args, "../examples/example00.txt")...)
+		if want := "invalid " + args[0] + " " + args[1] + "\n"; code != 1 || out != want {
+			t.Errorf("%v: got exit code %d, stdout %q; want the flag rejected", args, code, out)
+		}
+	}
+	if code, out, _ := runCmd("-max-rooms", "3", "-max-links", "3", "../examples/example00.txt"); code != 1 || out != "ERROR: invalid data format\n" {
+		t.Errorf("small caps: got exit code %d, stdout %q; want the map rejected", code, out)
+	}
+}
+
+func TestShowPaths(t *testing.T) {
+	path := "../examples/example05.txt"
+	code, _, errs := runCmd("-showpaths", path)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	_, info, _ := runCmd("-noecho", "-pathinfo", path)
+	var want []string
+	for _, l := range strings.Split(info, "\n") {
+		if rest, ok := strings.CutPrefix(l, "# path "); ok {
+			_, names, _ := strings.Cut(rest, ": ")
+			want = append(want, names)
+		}
+	}
+	got := strings.Split(strings.TrimSuffix(errs, "\n"), "\n")
+	if len(want) < 2 || strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("-showpaths printed\n%s\nwant the -pathinfo order\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	g, _, err := utils.ParseInput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range utils.FindPaths(g) {
+		if i >= len(got) || got[i] != strings.Join(pathNames(p), " ") {
+			t.Errorf("path %d differs from FindPaths", i)
+		}
+	}
+}
+
+// failWriter fails every write, like a full disk or a closed pipe.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestFailedFlush(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"../examples/example00.txt"}, failWriter{}, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if stderr.String() != "disk full\n" {
+		t.Errorf("stderr %q, want the write error", stderr.String())
+	}
+}
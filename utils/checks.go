@@ -1,71 +1,57 @@
 package utils
 
 import (
-	"fmt"
-	"os"
 	"strconv"
 	"strings"
 )
 
-func CheckStartOrEnd(line string, pendingStart bool, pendingEnd bool, g *Graph) (isStart, isEnd bool) {
+func CheckStartOrEnd(line string, pendingStart bool, pendingEnd bool, g *Graph) (isStart, isEnd bool, err error) {
 
 	if line == "##start" {
 		if pendingStart || g.Start != nil {
-			fmt.Println("ERROR: invalid data format")
-			fmt.Println("Reason: duplicate start")
-			os.Exit(1)
+			return false, false, invalid("command", 1, "duplicate start")
 		}
-		return true, false
+		return true, false, nil
 	} else if line == "##end" {
 		if pendingEnd || g.End != nil {
-			fmt.Println("ERROR: invalid data format")
-			fmt.Println("Reason: duplicate end")
-			os.Exit(1)
+			return false, false, invalid("command", 1, "duplicate end")
 		}
-		return false, true
+		return false, true, nil
 	}
 
-	return false, false
+	return false, false, nil
 }
 
-func CheckAnts(line string) (int, bool) {
+func CheckAnts(line string) (int, error) {
 	ants, err := strconv.Atoi(strings.TrimSpace(line))
-	if err != nil || ants <= 0 {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: invalid ants count")
-		os.Exit(1)
+	if err != nil || ants < 0 {
+		return 0, invalid("ants", fieldColumn(line, 0), "invalid ants count")
 	}
 	if ants > MaxAnts {
-		fmt.Println("ERROR: ant limit exceeded")
-		fmt.Println("Reason: ant count is more than " + strconv.Itoa(MaxAnts))
-		os.Exit(1)
+		return 0, invalid("limit", fieldColumn(line, 0), "ant count is more than "+strconv.Itoa(MaxAnts))
 	}
-	return ants, true
+	return ants, nil
 }
 
-func CheckRoom(pendingStart, pendingEnd bool, g *Graph, fields []string, coords map[[2]int]bool) (bool, bool, bool, *Graph) {
+func CheckRoom(pendingStart, pendingEnd bool, g *Graph, line string, coords map[[2]int]bool) (bool, bool, bool, *Graph, error) {
+	fields := strings.Fields(line)
 	name := fields[0]
 	if strings.HasPrefix(name, "L") || strings.HasPrefix(name, "#") {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: invalid room name '" + name + "'")
-		os.Exit(1)
+		return false, false, false, g, invalid("room", fieldColumn(line, 0), "invalid room name '"+name+"'")
 	}
 	if _, ok := g.Rooms[name]; ok {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: duplicate room name '" + name + "'")
-		os.Exit(1)
+		return false, false, false, g, invalid("room", fieldColumn(line, 0), "duplicate room name '"+name+"'")
 	}
 	x, err1 := strconv.Atoi(fields[1])
 	y, err2 := strconv.Atoi(fields[2])
-	if err1 != nil || err2 != nil {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: invalid room line")
-		os.Exit(1)
+	if err1 != nil {
+		return false, false, false, g, invalid("room", fieldColumn(line, 1), "invalid room line")
+	}
+	if err2 != nil {
+		return false, false, false, g, invalid("room", fieldColumn(line, 2), "invalid room line")
 	}
 	if coords[[2]int{x, y}] {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: duplicate coordinates " + fields[1] + " " + fields[2])
-		os.Exit(1)
+		return false, false, false, g, invalid("room", fieldColumn(line, 1), "duplicate coordinates "+fields[1]+" "+fields[2])
 	}
 	coords[[2]int{x, y}] = true
 	r := &Room{Name: name, X: x, Y: y}
@@ -78,26 +64,28 @@ func CheckRoom(pendingStart, pendingEnd bool, g *Graph, fields []string, coords
 		g.End = r
 		pendingEnd = false
 	}
-	return pendingStart, pendingEnd, coords[[2]int{x, y}], g
+	return pendingStart, pendingEnd, coords[[2]int{x, y}], g, nil
 
 }
 
-func CheckLink(linkSeen map[string]struct{}, links [][2]string, a, b string) ([][2]string, map[string]struct{}) {
+// CheckLink rejects a self-loop or a tunnel already given. linkSeen holds
+// every direction taken so far: a two-way link uses both, a one-way link
+// only a to b, so a->b and b->a may both be given.
+func CheckLink(linkSeen map[[2]string]struct{}, a, b string, oneWay bool) error {
 	if a == b {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: self-loop link " + a + "-" + b)
-		os.Exit(1)
+		return invalid("link", 1, "self-loop link "+a+"-"+b)
+	}
+	_, there := linkSeen[[2]string{a, b}]
+	_, back := linkSeen[[2]string{b, a}]
+	if oneWay && there {
+		return invalid("link", 1, "duplicate link "+a+"->"+b)
 	}
-	if b < a {
-		a, b = b, a
+	if !oneWay && (there || back) {
+		return invalid("link", 1, "duplicate link "+a+"-"+b)
 	}
-	key := a + "-" + b
-	if _, ok := linkSeen[key]; ok {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: duplicate link " + key)
-		os.Exit(1)
+	linkSeen[[2]string{a, b}] = struct{}{}
+	if !oneWay {
+		linkSeen[[2]string{b, a}] = struct{}{}
 	}
-	linkSeen[key] = struct{}{}
-	links = append(links, [2]string{a, b})
-	return links, linkSeen
+	return nil
 }
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToDOT describes the map as a Graphviz graph. The start and end rooms are
+// colored, and the tunnels used by the chosen paths are drawn in bold blue.
+// One-way tunnels get an arrow and slow ones are labeled with their cost.
+func ToDOT(g *Graph, chosen [][]*Room) string {
+	used := map[[2]*Room]bool{}
+	for _, p := range chosen {
+		for i := 1; i < len(p); i++ {
+			used[[2]*Room{p[i-1], p[i]}] = true
+			used[[2]*Room{p[i], p[i-1]}] = true
+		}
+	}
+
+	names := make([]string, 0, len(g.Rooms))
+	for name := range g.Rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("digraph lemin {\n")
+	for _, name := range names {
+		r := g.Rooms[name]
+		b.WriteString("\t" + strconv.Quote(name))
+		switch r {
+		case g.Start:
+			b.WriteString(" [shape=doublecircle, style=filled, fillcolor=green]")
+		case g.End:
+			b.WriteString(" [shape=doublecircle, style=filled, fillcolor=red]")
+		}
+		b.WriteString(";\n")
+	}
+	for _, name := range names {
+		r := g.Rooms[name]
+		links := append([]*Room{}, r.Links...)
+		sort.Slice(links, func(i, j int) bool { return links[i].Name < links[j].Name })
+		for _, nb := range links {
+			twoWay := hasNeighbor(nb, r)
+			if twoWay && nb.Name < r.Name {
+				continue // already written from the other side
+			}
+			var attrs []string
+			if twoWay {
+				attrs = append(attrs, "dir=none")
+			}
+			if c := stepCost(r, nb); c != 1 {
+				attrs = append(attrs, "label="+strconv.Quote(strconv.Itoa(c)))
+			}
+			if used[[2]*Room{r, nb}] {
+				attrs = append(attrs, "color=blue", "penwidth=2")
+			}
+			b.WriteString("\t" + strconv.Quote(r.Name) + " -> " + strconv.Quote(nb.Name))
+			if len(attrs) > 0 {
+				b.WriteString(" [" + strings.Join(attrs, ", ") + "]")
+			}
+			b.WriteString(";\n")
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
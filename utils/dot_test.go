@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestToDOT(t *testing.T) {
+	g := parseWeighted(t, `1
+##start
+s 0 0
+##end
+e 3 0
+a 1 0
+b 1 1
+s-a
+a-e
+s->b
+b-e:3
+`)
+	got := ToDOT(g, FindPaths(g))
+	want := `digraph lemin {
+	"a";
+	"b";
+	"e" [shape=doublecircle, style=filled, fillcolor=red];
+	"s" [shape=doublecircle, style=filled, fillcolor=green];
+	"a" -> "e" [dir=none, color=blue, penwidth=2];
+	"a" -> "s" [dir=none, color=blue, penwidth=2];
+	"b" -> "e" [dir=none, label="3"];
+	"s" -> "b";
+}
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
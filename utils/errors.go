@@ -0,0 +1,61 @@
+package utils
+
+import "fmt"
+
+// ParseError explains why a map was rejected and where. Line and Column
+// are 1-based; they are 0 when the problem is not tied to one line, such
+// as a missing ##start room. Kind is one of "ants", "limit", "command",
+// "room", "link", "line", "map" or "path", or "io" when the file itself
+// could not be read.
+type ParseError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Error returns the message, led by its position when it has one.
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// NoPathError is the error for a map where the end cannot be reached.
+func NoPathError() error {
+	return invalid("path", 0, "no path from start to end")
+}
+
+// invalid builds the error for a badly formatted map.
+func invalid(kind string, col int, reason string) error {
+	return &ParseError{Column: col, Kind: kind, Message: reason}
+}
+
+// atLine records the line number n on a ParseError.
+func atLine(err error, n int) error {
+	if pe, ok := err.(*ParseError); ok {
+		pe.Line = n
+	}
+	return err
+}
+
+// fieldColumn returns the 1-based column where the i-th space separated
+// field of line starts.
+func fieldColumn(line string, i int) int {
+	inField := false
+	for pos, c := range line {
+		if c == ' ' || c == '\t' {
+			inField = false
+			continue
+		}
+		if !inField {
+			if i == 0 {
+				return pos + 1
+			}
+			i--
+			inField = true
+		}
+	}
+	return 1
+}
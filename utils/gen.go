@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// GenerateMap returns a random valid map in the usual text format. The
+// rooms are first joined into a random tree, so the end is always reachable
+// from the start, and then up to extraEdges more links are added at random.
+// Rooms sit on a grid so the coordinates are unique and easy to draw. The
+// same seed always gives the same map.
+func GenerateMap(rooms, extraEdges, ants int, seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	if rooms < 2 {
+		rooms = 2
+	}
+	names := make([]string, rooms)
+	names[0], names[rooms-1] = "start", "end"
+	for i := 1; i < rooms-1; i++ {
+		names[i] = "r" + strconv.Itoa(i)
+	}
+
+	// spread the rooms over a square grid in random order
+	width := 1
+	for width*width < rooms {
+		width++
+	}
+	cells := rng.Perm(width * width)
+
+	seen := map[[2]int]bool{}
+	var links [][2]int
+	addLink := func(a, b int) {
+		if a == b {
+			return
+		}
+		if b < a {
+			a, b = b, a
+		}
+		if !seen[[2]int{a, b}] {
+			seen[[2]int{a, b}] = true
+			links = append(links, [2]int{a, b})
+		}
+	}
+	for i := 1; i < rooms; i++ {
+		addLink(i, rng.Intn(i))
+	}
+	if limit := rooms*(rooms-1)/2 - len(links); extraEdges > limit {
+		extraEdges = limit
+	}
+	for target := len(links) + extraEdges; len(links) < target; {
+		addLink(rng.Intn(rooms), rng.Intn(rooms))
+	}
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(ants) + "\n")
+	for i, name := range names {
+		if i == 0 {
+			b.WriteString("##start\n")
+		} else if i == rooms-1 {
+			b.WriteString("##end\n")
+		}
+		x, y := cells[i]%width*4, cells[i]/width*4
+		b.WriteString(name + " " + strconv.Itoa(x) + " " + strconv.Itoa(y) + "\n")
+	}
+	for _, l := range links {
+		b.WriteString(names[l[0]] + "-" + names[l[1]] + "\n")
+	}
+	return b.String()
+}
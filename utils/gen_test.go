@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateMap(t *testing.T) {
+	tests := []struct {
+		rooms, edges, ants int
+		links              int // links expected, or 0 to skip the check
+	}{
+		{0, 0, 3, 1}, // raised to the two rooms start and end
+		{1, 5, 3, 1}, // likewise, with no room for extra links
+		{2, 0, 1, 1},
+		{6, 100, 4, 15}, // capped at every pair of rooms linked
+		{30, 20, 10, 49},
+		{200, 300, 50, 0},
+	}
+	for _, tc := range tests {
+		for seed := int64(1); seed <= 3; seed++ {
+			text := GenerateMap(tc.rooms, tc.edges, tc.ants, seed)
+			if again := GenerateMap(tc.rooms, tc.edges, tc.ants, seed); again != text {
+				t.Errorf("rooms %d seed %d: same seed gave a different map", tc.rooms, seed)
+			}
+			g, lines, err := ParseReader(strings.NewReader(text))
+			if err != nil {
+				t.Fatalf("rooms %d seed %d: generated map rejected: %v", tc.rooms, seed, err)
+			}
+			if g.Ants != tc.ants || len(g.Rooms) != max(tc.rooms, 2) {
+				t.Errorf("rooms %d seed %d: got %d ants, %d rooms", tc.rooms, seed, g.Ants, len(g.Rooms))
+			}
+			if links := len(lines) - len(g.Rooms) - 3; tc.links > 0 && links != tc.links {
+				t.Errorf("rooms %d seed %d: got %d links, want %d", tc.rooms, seed, links, tc.links)
+			}
+			if len(FindPaths(g)) == 0 {
+				t.Errorf("rooms %d seed %d: no path from start to end", tc.rooms, seed)
+			}
+		}
+	}
+	if GenerateMap(30, 20, 10, 1) == GenerateMap(30, 20, 10, 2) {
+		t.Error("seeds 1 and 2 gave the same map")
+	}
+}
@@ -2,50 +2,114 @@ package utils
 
 import (
 	"bufio"
-	"fmt"
+	"bytes"
+	"compress/gzip"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 )
 
-func ParseInput(path string) (*Graph, []string) {
-	file, err := os.Open(path)
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+// ParseInput reads the map at path, or standard input when path is "-".
+// Gzip compressed maps are read transparently.
+func ParseInput(path string) (*Graph, []string, error) {
+	return ParseInputWith(path, ParseOptions{})
+}
+
+// ParseInputWith is ParseInput with the options in opts.
+func ParseInputWith(path string, opts ParseOptions) (*Graph, []string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		in = file
 	}
-	defer file.Close()
+	br := bufio.NewReader(in)
+	if magic, _ := br.Peek(2); strings.HasSuffix(path, ".gz") || bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer zr.Close()
+		return ParseReaderWith(zr, opts)
+	}
+	return ParseReaderWith(br, opts)
+}
+
+// gzipMagic is how every gzip stream starts.
+var gzipMagic = []byte{0x1f, 0x8b}
 
+// ParseReader reads a map from r and builds the graph. It also returns the
+// input lines so they can be printed back.
+func ParseReader(r io.Reader) (*Graph, []string, error) {
+	return ParseReaderWith(r, ParseOptions{})
+}
+
+// ParseReaderWith is ParseReader with the options in opts.
+func ParseReaderWith(r io.Reader, opts ParseOptions) (*Graph, []string, error) {
 	g := &Graph{Rooms: make(map[string]*Room)}
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanLines)
 	var lines []string
 	var pendingStart, pendingEnd bool
-	var links [][2]string
+	pendingLine := 0 // line of the ##start or ##end still waiting for its room
+	var links []link
 	parsedAnts := false
 	coords := map[[2]int]bool{}
-	linkSeen := map[string]struct{}{}
+	linkSeen := map[[2]string]struct{}{}
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\uFEFF") // byte order mark
+		}
 		lines = append(lines, line)
+		n := len(lines)
 		if strings.HasPrefix(line, "#") {
-			isStart, isEnd := CheckStartOrEnd(line, pendingStart, pendingEnd, g)
+			isStart, isEnd, err := CheckStartOrEnd(line, pendingStart, pendingEnd, g)
+			if err != nil {
+				return nil, nil, atLine(err, n)
+			}
+			if (isStart || isEnd) && (pendingStart || pendingEnd) {
+				return nil, nil, danglingCommand(pendingStart, pendingLine)
+			}
 			if isStart {
 				pendingStart = true
+				pendingLine = n
 			} else if isEnd {
 				pendingEnd = true
+				pendingLine = n
 			}
 			continue
 		}
 
+		// A ##start or ##end applies to the next room, even past blank lines
+		if strings.TrimSpace(line) == "" && (pendingStart || pendingEnd) {
+			continue
+		}
+
 		if !parsedAnts {
-			g.Ants, parsedAnts = CheckAnts(line)
+			ants, err := CheckAnts(line)
+			if err != nil {
+				return nil, nil, atLine(err, n)
+			}
+			g.Ants = ants
+			parsedAnts = true
 			continue
 		}
 
 		fields := strings.Fields(line)
 		if len(fields) == 3 {
-			isStart, isEnd, isCoords, newG := CheckRoom(pendingStart, pendingEnd, g, fields, coords)
+			if len(g.Rooms) >= opts.rooms() {
+				return nil, nil, atLine(invalid("room", 1, "more than "+strconv.Itoa(opts.rooms())+" rooms"), n)
+			}
+			isStart, isEnd, isCoords, newG, err := CheckRoom(pendingStart, pendingEnd, g, line, coords)
+			if err != nil {
+				return nil, nil, atLine(err, n)
+			}
 			if !isStart {
 				pendingStart = false
 			}
@@ -61,45 +125,168 @@ func ParseInput(path string) (*Graph, []string) {
 			continue
 		}
 
-		if strings.Count(line, "-") == 1 && !strings.Contains(line, " ") {
-			parts := strings.Split(line, "-")
-			newLinks, newSeen := CheckLink(linkSeen, links, parts[0], parts[1])
-			linkSeen = newSeen
-			links = newLinks
+		if l, ok := splitLink(line, opts.Weighted); ok {
+			if pendingStart || pendingEnd {
+				return nil, nil, danglingCommand(pendingStart, pendingLine)
+			}
+			if len(links) >= opts.links() {
+				return nil, nil, atLine(invalid("link", 1, "more than "+strconv.Itoa(opts.links())+" links"), n)
+			}
+			if err := CheckLink(linkSeen, l.from, l.to, l.oneWay); err != nil {
+				return nil, nil, atLine(err, n)
+			}
+			if l.cost < 1 {
+				return nil, nil, atLine(invalid("link", strings.LastIndex(line, ":")+2, "invalid link cost"), n)
+			}
+			l.line = n
+			links = append(links, l)
 			continue
 		}
 
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: invalid line")
-		os.Exit(1)
+		return nil, nil, atLine(invalid("line", 1, "invalid line"), n)
 	}
 	if err := scanner.Err(); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		return nil, nil, err
+	}
+	if pendingStart || pendingEnd {
+		return nil, nil, danglingCommand(pendingStart, pendingLine)
 	}
 
 	if g.Start == nil || g.End == nil {
-		fmt.Println("ERROR: invalid data format")
-		fmt.Println("Reason: missing start or end")
-		os.Exit(1)
+		return nil, nil, invalid("map", 0, "missing start or end")
 	}
 
 	for _, l := range links {
-		a, ok1 := g.Rooms[l[0]]
-		b, ok2 := g.Rooms[l[1]]
-		if !ok1 || !ok2 {
-			fmt.Println("ERROR: invalid data format")
-			fmt.Println("Reason: unknown room in link '" + l[0])
-			os.Exit(1)
+		a, ok1 := g.Rooms[l.from]
+		b, ok2 := g.Rooms[l.to]
+		if !ok1 {
+			return nil, nil, atLine(invalid("link", 1, "unknown room '"+l.from+"' in link"), l.line)
+		}
+		if !ok2 {
+			return nil, nil, atLine(invalid("link", l.toColumn(), "unknown room '"+l.to+"' in link"), l.line)
+		}
+		// "a-b:2" when a room "b:2" exists could mean either tunnel
+		if p, ok := splitRooms(l.whole); ok && g.Rooms[p.from] != nil && g.Rooms[p.to] != nil {
+			return nil, nil, atLine(invalid("link", p.toColumn(), "ambiguous link, room '"+p.to+"' also exists"), l.line)
 		}
 		if !hasNeighbor(a, b) {
 			a.Links = append(a.Links, b)
+			setCost(a, b, l.cost)
 		}
-		if !hasNeighbor(b, a) {
+		if !l.oneWay && !hasNeighbor(b, a) {
 			b.Links = append(b.Links, a)
+			setCost(b, a, l.cost)
+		}
+	}
+	return g, lines, nil
+}
+
+// scanLines splits input on "\n", "\r\n" or a lone "\r", so maps saved
+// by any editor read the same.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0 && atEOF:
+		return len(data), data, nil
+	case i < 0:
+		return 0, nil, nil
+	case data[i] == '\n':
+		return i + 1, data[:i], nil
+	case i+1 < len(data) && data[i+1] == '\n':
+		return i + 2, data[:i], nil
+	case i+1 < len(data) || atEOF:
+		return i + 1, data[:i], nil
+	}
+	// a "\r" at the end of the buffer: wait to see if "\n" follows
+	return 0, nil, nil
+}
+
+// link is a tunnel read from the map, kept until all rooms are known.
+type link struct {
+	from, to string
+	oneWay   bool   // the tunnel only leads from -> to
+	cost     int    // turns needed to cross it
+	line     int    // line number, for errors
+	whole    string // the full text when a ":n" was read as the cost
+}
+
+// splitLink reads a link line. "a-b" joins both ways, while "a->b" is a
+// one-way tunnel from a to b. With weighted, either may end in ":n" for a
+// tunnel that takes n turns to cross. Only an all-digit n counts, so a room
+// name may still hold a ":"; an n of 0 or one too large gives a cost of 0.
+func splitLink(line string, weighted bool) (link, bool) {
+	if strings.Contains(line, " ") {
+		return link{}, false
+	}
+	if i := strings.LastIndex(line, ":"); weighted && i >= 0 && allDigits(line[i+1:]) {
+		if l, ok := splitRooms(line[:i]); ok {
+			cost, err := strconv.Atoi(line[i+1:])
+			if err != nil {
+				cost = 0 // too large
+			}
+			l.cost, l.whole = cost, line
+			return l, true
+		}
+	}
+	l, ok := splitRooms(line)
+	l.cost = 1
+	return l, ok
+}
+
+// splitRooms splits "a-b" or "a->b" into its two room names.
+func splitRooms(line string) (link, bool) {
+	if from, to, found := strings.Cut(line, "->"); found && !strings.Contains(to, "-") {
+		return link{from: from, to: to, oneWay: true}, true
+	}
+	if strings.Count(line, "-") != 1 {
+		return link{}, false
+	}
+	from, to, _ := strings.Cut(line, "-")
+	return link{from: from, to: to}, true
+}
+
+// allDigits reports whether s is a non-empty run of ASCII digits.
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
 		}
 	}
-	return g, lines
+	return true
+}
+
+// toColumn returns the 1-based column where the second room name starts.
+func (l link) toColumn() int {
+	if l.oneWay {
+		return len(l.from) + 3
+	}
+	return len(l.from) + 2
+}
+
+// setCost records a tunnel that takes more than one turn to cross.
+func setCost(a, b *Room, cost int) {
+	if cost == 1 {
+		return
+	}
+	if a.Costs == nil {
+		a.Costs = map[*Room]int{}
+	}
+	a.Costs[b] = cost
+}
+
+// danglingCommand reports a ##start or ##end that no room follows.
+func danglingCommand(start bool, line int) error {
+	name := "##end"
+	if start {
+		name = "##start"
+	}
+	return atLine(invalid("command", 1, name+" is not followed by a room"), line)
 }
 
 func hasNeighbor(r, other *Room) bool {
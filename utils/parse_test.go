@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// parseErr parses a map that must be rejected and returns its error.
+func parseErr(t *testing.T, text string) *ParseError {
+	t.Helper()
+	_, _, err := ParseReader(strings.NewReader(text))
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("got error %v, want a *ParseError", err)
+	}
+	return pe
+}
+
+func TestUnknownRoomError(t *testing.T) {
+	const head = "1\n##start\ns 0 0\n##end\ne 1 1\n"
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"s-zz", "line 6, column 3: unknown room 'zz' in link"},
+		{"s->zz", "line 6, column 4: unknown room 'zz' in link"},
+		{"zz-e", "line 6, column 1: unknown room 'zz' in link"},
+	}
+	for _, tc := range tests {
+		if got := parseErr(t, head+tc.link+"\n").Error(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.link, got, tc.want)
+		}
+	}
+}
+
+func TestDirectiveSkipsCommentsAndBlankLines(t *testing.T) {
+	g := parseMap(t, "1\n##start\n# a comment\n\ns 0 0\n##end\n\n# another\ne 1 1\ns-e\n")
+	if g.Start.Name != "s" || g.End.Name != "e" {
+		t.Errorf("got start %q, end %q, want s and e", g.Start.Name, g.End.Name)
+	}
+}
+
+func TestDirectiveWithoutRoom(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"at end of file", "1\n##start\ns 0 0\n##end\n", "line 4, column 1: ##end is not followed by a room"},
+		{"before a link", "1\n##start\ns 0 0\ne 1 1\ns-e\n##end\n", "line 6, column 1: ##end is not followed by a room"},
+		{"before a directive", "1\n##start\n##end\ns 0 0\ne 1 1\ns-e\n", "line 2, column 1: ##start is not followed by a room"},
+	}
+	for _, tc := range tests {
+		if got := parseErr(t, tc.text).Error(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseGzip(t *testing.T) {
+	plain, plainLines, err := ParseInput(filepath.Join("..", "examples", "example01.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, lines, err := ParseInput(filepath.Join("..", "examples", "example01.txt.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, "\n") != strings.Join(plainLines, "\n") {
+		t.Error("gzip map lines differ from the plain map")
+	}
+	if g.Ants != plain.Ants || len(g.Rooms) != len(plain.Rooms) {
+		t.Errorf("got %d ants and %d rooms, want %d and %d", g.Ants, len(g.Rooms), plain.Ants, len(plain.Rooms))
+	}
+
+	// Without the .gz suffix the gzip header alone gives it away
+	data, err := os.ReadFile(filepath.Join("..", "examples", "example01.txt.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, lines, err = ParseInput(path); err != nil || len(lines) != len(plainLines) {
+		t.Errorf("got %d lines, error %v; want %d lines", len(lines), err, len(plainLines))
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	const text = "1\n##start\ns 0 0\nm 1 0\n##end\ne 2 0\ns-m\nm-e\n"
+	tests := []struct {
+		lim  Limits
+		want string
+	}{
+		{Limits{Rooms: 2}, "line 6, column 1: more than 2 rooms"},
+		{Limits{Links: 1}, "line 8, column 1: more than 1 links"},
+	}
+	for _, tc := range tests {
+		_, _, err := ParseReaderWith(strings.NewReader(text), ParseOptions{Limits: tc.lim})
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%+v: got %v, want %q", tc.lim, err, tc.want)
+		}
+	}
+	if _, _, err := ParseReaderWith(strings.NewReader(text), ParseOptions{Limits: Limits{Rooms: 3, Links: 2}}); err != nil {
+		t.Errorf("map at the caps: %v", err)
+	}
+}
+
+// TestLineEndings reads example00 saved with other line endings and a byte
+// order mark, whole and one byte at a time so a "\r\n" split across reads
+// is covered too.
+func TestLineEndings(t *testing.T) {
+	_, want, err := ParseInput(filepath.Join("..", "examples", "example00.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"example00_crlf.txt", "example00_cr.txt", "example00_bom.txt"} {
+		data, err := os.ReadFile(filepath.Join("..", "examples", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range []io.Reader{bytes.NewReader(data), iotest.OneByteReader(bytes.NewReader(data))} {
+			_, lines, err := ParseReader(r)
+			if err != nil {
+				t.Errorf("%s: %v", file, err)
+				continue
+			}
+			if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+				t.Errorf("%s: got lines %q, want %q", file, lines, want)
+			}
+		}
+	}
+}
+
+func TestScanLinesSplitCRLF(t *testing.T) {
+	// a "\r" at the end of the buffer must wait for the next byte
+	if adv, tok, _ := scanLines([]byte("ab\r"), false); adv != 0 || tok != nil {
+		t.Errorf("got advance %d, token %q; want to wait for more", adv, tok)
+	}
+	if adv, tok, _ := scanLines([]byte("ab\r"), true); adv != 3 || string(tok) != "ab" {
+		t.Errorf("at EOF got advance %d, token %q; want 3, \"ab\"", adv, tok)
+	}
+}
+
+func TestSplitLink(t *testing.T) {
+	tests := []struct {
+		line     string
+		weighted bool
+		want     link
+		ok       bool
+	}{
+		{"a-b", true, link{from: "a", to: "b", cost: 1}, true},
+		{"a->b", true, link{from: "a", to: "b", oneWay: true, cost: 1}, true},
+		{"a-b:3", true, link{from: "a", to: "b", cost: 3}, true},
+		{"a->b:2", true, link{from: "a", to: "b", oneWay: true, cost: 2}, true},
+		{"a-b:0", true, link{from: "a", to: "b", cost: 0}, true},
+		{"a-b:99999999999999999999", true, link{from: "a", to: "b", cost: 0}, true},
+		{"s:1-e", true, link{from: "s:1", to: "e", cost: 1}, true},
+		{"s:1-e:2", true, link{from: "s:1", to: "e", cost: 2}, true},
+		{"a-b:x", true, link{from: "a", to: "b:x", cost: 1}, true},
+		{"a-b-c:2", true, link{}, false},
+		{"a b-c", true, link{}, false},
+		// without weighted a ":n" is part of the room name
+		{"a-b:3", false, link{from: "a", to: "b:3", cost: 1}, true},
+		{"s-e:1", false, link{from: "s", to: "e:1", cost: 1}, true},
+	}
+	for _, tc := range tests {
+		got, ok := splitLink(tc.line, tc.weighted)
+		got.whole = ""
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("%q (weighted %v): got %+v, %v; want %+v, %v", tc.line, tc.weighted, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestWeightedLinkRooms(t *testing.T) {
+	const head = "1\n##start\ns 0 0\n##end\ne:1 1 1\n"
+	// a plain map keeps its room names
+	g := parseMap(t, head+"s-e:1\n")
+	if !hasNeighbor(g.Start, g.End) {
+		t.Error("s-e:1 did not link s to the room e:1")
+	}
+	// with weighted the same line could also mean s-e taking one turn
+	text := head + "e 2 2\ns-e:1\n"
+	_, _, err := ParseReaderWith(strings.NewReader(text), ParseOptions{Weighted: true})
+	if want := "line 7, column 3: ambiguous link, room 'e:1' also exists"; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestDuplicateLinks(t *testing.T) {
+	const head = "1\n##start\ns 0 0\n##end\ne 1 1\na 2 2\ns-a\n"
+	// opposite one-way tunnels are two different tunnels
+	g := parseMap(t, head+"a->e\ne->a\n")
+	if !hasNeighbor(g.Rooms["a"], g.End) || !hasNeighbor(g.End, g.Rooms["a"]) {
+		t.Error("a->e and e->a did not both link")
+	}
+	tests := []struct {
+		links string
+		want  string
+	}{
+		{"a-e\ne-a\n", "line 9, column 1: duplicate link e-a"},
+		{"a->e\na->e\n", "line 9, column 1: duplicate link a->e"},
+		{"a-e\ne->a\n", "line 9, column 1: duplicate link e->a"},
+		{"a->e\ne-a\n", "line 9, column 1: duplicate link e-a"},
+	}
+	for _, tc := range tests {
+		if got := parseErr(t, head+tc.links).Error(); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.links, got, tc.want)
+		}
+	}
+}
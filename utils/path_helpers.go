@@ -2,16 +2,32 @@ package utils
 
 // These helpers help assign ants to paths in a simple way.
 
-// getLens returns how many steps are in each path (rooms minus one).
+// getLens returns how many turns each path takes to walk.
 func getLens(paths [][]*Room) []int {
 	lens := make([]int, len(paths))
 	for i, path := range paths {
-		// each path length is number of rooms minus the start room
-		lens[i] = len(path) - 1
+		lens[i] = pathCost(path)
 	}
 	return lens
 }
 
+// stepCost returns how many turns it takes to go from a to the linked room b.
+func stepCost(a, b *Room) int {
+	if c, ok := a.Costs[b]; ok {
+		return c
+	}
+	return 1
+}
+
+// pathCost returns how many turns it takes to walk a whole path.
+func pathCost(path []*Room) int {
+	total := 0
+	for i := 1; i < len(path); i++ {
+		total += stepCost(path[i-1], path[i])
+	}
+	return total
+}
+
 // countStarts figures out how many ants can start on each path.
 func countStarts(lens []int, turns int) []int {
 	starts := make([]int, len(lens))
@@ -45,6 +61,14 @@ func trimStarts(starts []int, ants int) []int {
 	return starts
 }
 
+// PathLoads reports how many ants each path carries. Every path is filled
+// up to the common turn count, so a longer path is used from the first turn
+// whenever that finishes sooner than queueing on a shorter one.
+func PathLoads(paths [][]*Room, ants int) []int {
+	lens := getLens(paths)
+	return trimStarts(countStarts(lens, countTurns(ants, lens)), ants)
+}
+
 // planOrder builds the order of path indices for ant departure.
 func planOrder(starts []int) []int {
 	var order []int
@@ -7,6 +7,21 @@ func allPaths(g *Graph, limit int) [][]*Room {
 	path := []*Room{}           // Current path we are walking
 	visited := map[*Room]bool{} // List of rooms we already visited
 
+	// Walk links in room name order so the paths found, and so the whole
+	// solution, are the same on every run
+	links := map[*Room][]*Room{}
+	for _, r := range g.Rooms {
+		sorted := append([]*Room{}, r.Links...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+		links[r] = sorted
+	}
+
+	// Rooms that can reach the exit at all. On a two-way map every room
+	// the search gets to can, so this only cuts off one-way dead ends
+	toEnd := reachingEnd(g)
+
 	var dfs func(*Room)
 	dfs = func(r *Room) {
 		// Stop if we found enough paths
@@ -25,9 +40,10 @@ func allPaths(g *Graph, limit int) [][]*Room {
 		visited[r] = true
 		path = append(path, r)
 
-		// Try all connected rooms that we haven't visited
-		for _, nb := range r.Links {
-			if !visited[nb] {
+		// Try all connected rooms that we haven't visited and that
+		// have some way on to the exit
+		for _, nb := range links[r] {
+			if !visited[nb] && toEnd[nb] {
 				dfs(nb) // Go explore that room
 			}
 		}
@@ -40,7 +56,7 @@ func allPaths(g *Graph, limit int) [][]*Room {
 	return res   // Return all paths found
 }
 
-func bestDisjointPaths(all [][]*Room, ants int) [][]*Room {
+func bestDisjointPaths(all [][]*Room, ants, max int) [][]*Room {
 	bestTurns := int(^uint(0) >> 1) // Very big number (worst case)
 	var best [][]*Room              // Best paths we found
 	var bestIdx []int               // Which path numbers we picked
@@ -49,18 +65,16 @@ func bestDisjointPaths(all [][]*Room, ants int) [][]*Room {
 	// This function finds the best combination of paths for ants to use
 	rec = func(i int, cur [][]*Room, idxs []int, used map[*Room]bool) {
 
-		// BASE CASE: We've looked at all available paths
-		if i == len(all) {
+		// BASE CASE: We've looked at all available paths,
+		// or no more paths can fit next to the ones we picked
+		if i == len(all) || len(cur) == max {
 			// Skip empty combinations
 			if len(cur) == 0 {
 				return
 			}
 
-			// Calculate path lengths (subtract 1 because we count steps, not rooms)
-			lens := make([]int, len(cur))
-			for j, path := range cur {
-				lens[j] = len(path) - 1
-			}
+			// Calculate how many turns each path takes
+			lens := getLens(cur)
 
 			// Calculate total turns needed for this combination
 			t := countTurns(ants, lens)
@@ -135,8 +149,48 @@ func bestDisjointPaths(all [][]*Room, ants int) [][]*Room {
 	return best                        // Return best combination
 }
 
+// PathLimit drops candidate paths that take more than Max turns before the
+// best set is chosen, which makes the search smaller but can cost turns.
+// With Relative, Max is counted on top of the shortest path, so +0 keeps
+// only the shortest paths. Otherwise a zero Max means no limit.
+type PathLimit struct {
+	Max      int
+	Relative bool
+}
+
 // Main function to find the best paths for ants to use
 func FindPaths(g *Graph) [][]*Room {
+	return FindPathsWithin(g, PathLimit{})
+}
+
+// FindPathsWithin is FindPaths with candidate paths limited by lim.
+func FindPathsWithin(g *Graph, lim PathLimit) [][]*Room {
+	return findPaths(g, g.Ants, lim)
+}
+
+// MinTurns returns how many turns the solver needs to bring ants ants to
+// the end, without working out the moves. It is 0 for no ants or no path.
+// It matches the turn count SimulateMulti returns, which on weighted maps
+// can be more than its number of lines.
+func (g *Graph) MinTurns(ants int) int {
+	paths := findPaths(g, ants, PathLimit{})
+	if ants <= 0 || len(paths) == 0 {
+		return 0
+	}
+	if len(paths[0]) == 2 && paths[0][1] == g.End && stepCost(g.Start, g.End) == 1 {
+		return 1 // every ant crosses the start-end tunnel at once
+	}
+	return countTurns(ants, getLens(paths))
+}
+
+// findPaths picks the paths for moving ants ants.
+func findPaths(g *Graph, ants int, lim PathLimit) [][]*Room {
+
+	// A tunnel straight from start to end carries every ant in one turn,
+	// so no other path can do better
+	if hasNeighbor(g.Start, g.End) && stepCost(g.Start, g.End) == 1 {
+		return [][]*Room{{g.Start, g.End}}
+	}
 
 	// Generate all possible paths from start to end
 	// MaxPaths limits how many paths we consider (for performance)
@@ -144,7 +198,7 @@ func FindPaths(g *Graph) [][]*Room {
 
 	// Sort paths by length (shortest first)
 	sort.SliceStable(all, func(i, j int) bool {
-		li, lj := len(all[i]), len(all[j]) // Get lengths of both paths
+		li, lj := pathCost(all[i]), pathCost(all[j]) // Get lengths of both paths
 
 		// If paths have same length, keep original order (stable sort)
 		if li == lj {
@@ -155,8 +209,47 @@ func FindPaths(g *Graph) [][]*Room {
 		return li < lj
 	})
 
-	// Find the best combination of non-overlapping paths
-	return bestDisjointPaths(all, g.Ants)
+	// Drop paths that are too long, unless too few would be left
+	all = limitPaths(all, lim, maxSelected(g))
+
+	// Find the best combination of non-overlapping paths. It keeps the
+	// order of all, so the shortest path comes first and gets the lowest
+	// numbered ants
+	return bestDisjointPaths(all, ants, maxSelected(g))
+}
+
+// limitPaths keeps the paths allowed by lim. all must be sorted shortest
+// first. If fewer than want paths are short enough, all of them are kept,
+// so the limit never leaves the map without a route or with fewer
+// candidates than could run side by side.
+func limitPaths(all [][]*Room, lim PathLimit, want int) [][]*Room {
+	if (lim.Max <= 0 && !lim.Relative) || len(all) == 0 {
+		return all
+	}
+	max := lim.Max
+	if lim.Relative {
+		max += pathCost(all[0])
+	}
+	n := 0
+	for n < len(all) && pathCost(all[n]) <= max {
+		n++
+	}
+	if n == 0 || n < want {
+		return all
+	}
+	return all[:n]
+}
+
+// maxSelected returns how many paths can be used side by side. Every path
+// needs its own tunnel out of the start and into the end.
+func maxSelected(g *Graph) int {
+	into := 0
+	for _, r := range g.Rooms {
+		if hasNeighbor(r, g.End) {
+			into++
+		}
+	}
+	return min(len(g.Start.Links), into)
 }
 
 // countTurns returns how many turns are needed for given paths and ants.
@@ -174,16 +267,19 @@ func countTurns(ants int, lens []int) int {
 	}
 }
 
+// AntsByPath lists, for each path, the numbers of the ants that take it.
+func AntsByPath(paths [][]*Room, ants int) [][]int {
+	byPath := make([][]int, len(paths))
+	for ant, p := range assignPaths(paths, ants) {
+		byPath[p] = append(byPath[p], ant+1)
+	}
+	return byPath
+}
+
 // assignPaths picks a path index for every ant.
 func assignPaths(paths [][]*Room, ants int) []int {
-	// find length of each path
-	lens := getLens(paths)
-	// how many turns are needed in total
-	turns := countTurns(ants, lens)
-	// how many ants can start on each path
-	starts := countStarts(lens, turns)
-	// trim if we planned for more ants than we have
-	starts = trimStarts(starts, ants)
+	// how many ants each path takes
+	starts := PathLoads(paths, ants)
 	// make the order in which ants should leave
 	return planOrder(starts)
 }
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseMap parses a map given as text.
+func parseMap(t testing.TB, text string) *Graph {
+	t.Helper()
+	g, _, err := ParseReader(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return g
+}
+
+// parseWeighted parses a map given as text, reading ":n" link costs.
+func parseWeighted(t testing.TB, text string) *Graph {
+	t.Helper()
+	g, _, err := ParseReaderWith(strings.NewReader(text), ParseOptions{Weighted: true})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return g
+}
+
+// solveText solves g and returns its moves, one turn per line.
+func solveText(g *Graph) string {
+	moves, _ := SimulateMulti(g, FindPaths(g))
+	return strings.Join(moves, "\n")
+}
+
+func TestMaxSelectedStartDegree(t *testing.T) {
+	// The start has two tunnels, but they fan out into many routes that
+	// reach the end through four different rooms.
+	g := parseMap(t, `10
+##start
+s 0 0
+##end
+e 9 9
+a 1 0
+b 1 1
+x1 2 0
+x2 2 1
+x3 2 2
+x4 2 3
+s-a
+s-b
+a-x1
+a-x2
+a-x3
+a-x4
+b-x1
+b-x2
+b-x3
+b-x4
+x1-e
+x2-e
+x3-e
+x4-e
+`)
+	if got := maxSelected(g); got != 2 {
+		t.Fatalf("maxSelected = %d, want 2", got)
+	}
+	paths := FindPaths(g)
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want 2", len(paths))
+	}
+	// 10 ants over two 3-step paths: 5 each, the last arriving on turn 7
+	if _, got := SimulateMulti(g, paths); got != 7 {
+		t.Errorf("got %d turns, want 7", got)
+	}
+}
+
+func TestOneWayDetour(t *testing.T) {
+	// a is one tunnel from both ends, but e->a only leads away from the
+	// end, so the ant has to go round through b and c.
+	g := parseMap(t, `1
+##start
+s 0 0
+##end
+e 3 0
+a 1 0
+b 1 1
+c 2 1
+s-a
+e->a
+s-b
+b-c
+c-e
+`)
+	got := solveText(g)
+	if want := "L1-b\nL1-c\nL1-e"; got != want {
+		t.Errorf("got moves\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWeightedTunnel(t *testing.T) {
+	// s-a takes two turns, so the first turn has no visible move and
+	// prints no line, yet it still counts as a turn.
+	g := parseWeighted(t, `2
+##start
+s 0 0
+##end
+e 3 0
+a 1 0
+s-a:2
+a-e
+`)
+	moves, turns := SimulateMulti(g, FindPaths(g))
+	if got, want := strings.Join(moves, "\n"), "L1-a\nL1-e L2-a\nL2-e"; got != want {
+		t.Errorf("got moves\n%s\nwant\n%s", got, want)
+	}
+	if turns != 4 || g.MinTurns(g.Ants) != 4 {
+		t.Errorf("simulated %d turns, MinTurns = %d; want 4 for both", turns, g.MinTurns(g.Ants))
+	}
+
+	// With s-a:4 and 3 ants three of the seven turns print nothing
+	g = parseWeighted(t, "3\n##start\ns 0 0\n##end\ne 3 0\na 1 0\ns-a:4\na-e\n")
+	moves, turns = SimulateMulti(g, FindPaths(g))
+	if len(moves) != 4 || turns != 7 || g.MinTurns(g.Ants) != 7 {
+		t.Errorf("got %d lines, %d turns, MinTurns %d; want 4, 7, 7", len(moves), turns, g.MinTurns(g.Ants))
+	}
+}
+
+func TestWeightedTunnelAvoided(t *testing.T) {
+	// The direct-looking route through a costs 5 turns; the two tunnel
+	// detour through b and c is quicker.
+	g := parseWeighted(t, `1
+##start
+s 0 0
+##end
+e 3 0
+a 1 0
+b 1 1
+c 2 1
+s-a:5
+a-e
+s-b
+b-c
+c-e
+`)
+	got := solveText(g)
+	if want := "L1-b\nL1-c\nL1-e"; got != want {
+		t.Errorf("got moves\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPathLimitChangesPaths(t *testing.T) {
+	// Both paths of at most 3 turns go through a, so with +1 only one of
+	// them is used and the ants queue on it; without a limit the long
+	// route through c, d and f runs beside s-a-e.
+	g := parseMap(t, `10
+##start
+s 0 0
+##end
+e 5 0
+a 1 0
+b 1 1
+c 2 1
+d 3 1
+f 4 1
+s-a
+a-e
+s-b
+b-a
+b-c
+c-d
+d-f
+f-e
+`)
+	if got := len(FindPaths(g)); got != 2 {
+		t.Fatalf("no limit: got %d paths, want 2", got)
+	}
+	limited := FindPathsWithin(g, PathLimit{Max: 1, Relative: true})
+	if len(limited) != 1 || len(limited[0]) != 3 {
+		t.Fatalf("+1: got %d paths, want only s-a-e", len(limited))
+	}
+	if _, turns := SimulateMulti(g, limited); turns != 11 {
+		t.Errorf("+1: got %d turns, want 11", turns)
+	}
+}
+
+func TestPathLimitExample05(t *testing.T) {
+	// +2 leaves enough paths to choose from and picks a different set
+	g := loadExample(t, "example05.txt")
+	names := func(paths [][]*Room) string {
+		var s []string
+		for _, p := range paths {
+			for _, r := range p {
+				s = append(s, r.Name)
+			}
+			s = append(s, "|")
+		}
+		return strings.Join(s, " ")
+	}
+	if names(FindPathsWithin(g, PathLimit{Max: 2, Relative: true})) == names(FindPaths(g)) {
+		t.Error("+2 chose the same paths as no limit")
+	}
+}
+
+func TestLimitPaths(t *testing.T) {
+	all := [][]*Room{chain(3), chain(3), chain(5)}
+	tests := []struct {
+		lim  PathLimit
+		want int
+		kept int
+	}{
+		{PathLimit{Relative: true}, 2, 2}, // +0 keeps only the shortest
+		{PathLimit{Relative: true}, 3, 3}, // too few left, so keep all
+		{PathLimit{Max: 1}, 1, 3},         // none short enough
+		{PathLimit{}, 1, 3},               // no limit
+	}
+	for _, tc := range tests {
+		if got := limitPaths(all, tc.lim, tc.want); len(got) != tc.kept {
+			t.Errorf("%+v, want %d: kept %d paths, want %d", tc.lim, tc.want, len(got), tc.kept)
+		}
+	}
+}
+
+// TestMinTurnsMatchesSimulation checks MinTurns against the turns actually
+// simulated for several ant counts on each small example.
+func TestMinTurnsMatchesSimulation(t *testing.T) {
+	for _, file := range []string{"example00.txt", "example01.txt", "example02.txt", "example04.txt", "example05.txt", "example06.txt"} {
+		g := loadExample(t, file)
+		for _, ants := range []int{1, 2, 3, 5, 8, 13, 50, 200} {
+			g.Ants = ants
+			_, want := SimulateMulti(g, FindPaths(g))
+			if got := g.MinTurns(ants); got != want {
+				t.Errorf("%s with %d ants: MinTurns = %d, simulation took %d", file, ants, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+// Prune removes rooms no path from start to end can use: rooms the start
+// cannot reach and rooms from which the end cannot be reached. It returns
+// how many rooms were removed. Start and end are never removed; if either
+// is cut off from the other the map is invalid instead.
+func (g *Graph) Prune() (int, error) {
+	if len(g.Start.Links) == 0 || !hasLinkInto(g, g.End) {
+		return 0, invalid("map", 0, "start or end room has no links")
+	}
+	fromStart := reachable(g.Start, func(r *Room) []*Room { return r.Links })
+	toEnd := reachingEnd(g)
+	if !fromStart[g.End] {
+		return 0, NoPathError()
+	}
+
+	pruned := 0
+	for name, r := range g.Rooms {
+		if !fromStart[r] || !toEnd[r] {
+			delete(g.Rooms, name)
+			pruned++
+		}
+	}
+	for _, r := range g.Rooms {
+		kept := r.Links[:0]
+		for _, nb := range r.Links {
+			if _, ok := g.Rooms[nb.Name]; ok {
+				kept = append(kept, nb)
+			}
+		}
+		r.Links = kept
+	}
+	return pruned, nil
+}
+
+// reachingEnd returns every room from which the end can be reached.
+func reachingEnd(g *Graph) map[*Room]bool {
+	// rooms leading into each room, so we can walk backwards from the end
+	back := map[*Room][]*Room{}
+	for _, r := range g.Rooms {
+		for _, nb := range r.Links {
+			back[nb] = append(back[nb], r)
+		}
+	}
+	return reachable(g.End, func(r *Room) []*Room { return back[r] })
+}
+
+// hasLinkInto reports whether any room links to r.
+func hasLinkInto(g *Graph, r *Room) bool {
+	for _, other := range g.Rooms {
+		if hasNeighbor(other, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable returns every room that can be reached from r by following next.
+func reachable(r *Room, next func(*Room) []*Room) map[*Room]bool {
+	seen := map[*Room]bool{r: true}
+	queue := []*Room{r}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, nb := range next(cur) {
+			if !seen[nb] {
+				seen[nb] = true
+				queue = append(queue, nb)
+			}
+		}
+	}
+	return seen
+}
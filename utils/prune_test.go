@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"sort"
+	"testing"
+)
+
+// roomNames lists the rooms left in g, sorted.
+func roomNames(g *Graph) []string {
+	var names []string
+	for name := range g.Rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPruneOtherComponent(t *testing.T) {
+	g := parseMap(t, "1\n##start\ns 0 0\n##end\ne 2 0\na 1 0\nx 5 5\ny 6 6\ns-a\na-e\nx-y\n")
+	n, err := g.Prune()
+	if err != nil || n != 2 {
+		t.Fatalf("got %d pruned, %v; want 2", n, err)
+	}
+	if got := roomNames(g); len(got) != 3 || got[0] != "a" || got[1] != "e" || got[2] != "s" {
+		t.Errorf("rooms left %v, want [a e s]", got)
+	}
+}
+
+func TestPruneOneWay(t *testing.T) {
+	// b can be entered from the start but leads nowhere; c leads to the
+	// end but cannot be entered. Only reverse reachability catches b.
+	g := parseMap(t, `1
+##start
+s 0 0
+##end
+e 2 0
+a 1 0
+b 1 1
+c 1 2
+s->a
+a->e
+s->b
+e->b
+c->e
+`)
+	n, err := g.Prune()
+	if err != nil || n != 2 {
+		t.Fatalf("got %d pruned, %v; want 2", n, err)
+	}
+	if _, ok := g.Rooms["b"]; ok {
+		t.Error("b was kept")
+	}
+	if _, ok := g.Rooms["c"]; ok {
+		t.Error("c was kept")
+	}
+	for _, nb := range g.Start.Links {
+		if nb.Name == "b" {
+			t.Error("start still links to the pruned room b")
+		}
+	}
+	if got := solveText(g); got != "L1-a\nL1-e" {
+		t.Errorf("got moves %q after pruning", got)
+	}
+}
+
+func TestPruneErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"start without links", "1\n##start\ns 0 0\n##end\ne 2 0\na 1 0\na-e\n", "start or end room has no links"},
+		{"end without links", "1\n##start\ns 0 0\n##end\ne 2 0\na 1 0\ns-a\n", "start or end room has no links"},
+		{"end out of reach", "1\n##start\ns 0 0\n##end\ne 2 0\na 1 0\nb 1 1\ns-a\nb-e\n", "no path from start to end"},
+		{"only a link out of the end", "1\n##start\ns 0 0\n##end\ne 2 0\na 1 0\ns-a\ne->a\n", "start or end room has no links"},
+	}
+	for _, tc := range tests {
+		g := parseMap(t, tc.text)
+		rooms := len(g.Rooms)
+		n, err := g.Prune()
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.want)
+		}
+		if n != 0 || len(g.Rooms) != rooms {
+			t.Errorf("%s: the graph changed on error", tc.name)
+		}
+	}
+}
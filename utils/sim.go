@@ -1,10 +1,15 @@
 package utils
 
-// SimulateMulti runs the ant simulation for multiple paths.
-func SimulateMulti(g *Graph, paths [][]*Room) []string {
+// SimulateMulti runs the ant simulation for multiple paths. It returns the
+// move lines and how many turns were simulated. A turn where every moving
+// ant is inside a slow tunnel prints no line, so on weighted maps there can
+// be fewer lines than turns.
+func SimulateMulti(g *Graph, paths [][]*Room) ([]string, int) {
 	if len(paths) == 0 {
-		return nil
+		return nil, 0
 	}
+	// a slow tunnel is walked one hidden step per turn
+	paths = expandPaths(paths)
 	// plan tells which path each ant will take
 	plan := assignPaths(paths, g.Ants)
 	// wait holds ants waiting to start on each path
@@ -17,12 +22,13 @@ func SimulateMulti(g *Graph, paths [][]*Room) []string {
 	busy := map[*Room]int{}          // rooms currently occupied
 	done := 0                        // number of ants finished
 	var out []string                 // output lines
-	for done < len(plan) {
+	turns := 0
+	for ; done < len(plan); turns++ {
 		moves := moveAnts(g, paths, loc, going, busy, &done, plan)
 		moves = append(moves, startAnts(g, paths, wait, going, loc, busy, &done)...)
-		if len(moves) > 0 {
-			out = append(out, formatMoves(moves))
+		if line := formatMoves(moves); line != "" {
+			out = append(out, line)
 		}
 	}
-	return out
+	return out, turns
 }
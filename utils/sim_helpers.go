@@ -48,16 +48,23 @@ func startAnts(g *Graph, paths [][]*Room, wait [][]int, going []bool, loc []int,
 		if len(q) == 0 {
 			continue
 		}
-		ant := q[0]
 		next := paths[i][1]
-		if next == g.End || busy[next] == 0 {
-			going[ant] = true
-			loc[ant] = 1
-			if next != g.End {
-				busy[next] = ant + 1
-			} else {
+		if next == g.End {
+			// a tunnel straight to the end lets every waiting ant through at once
+			for _, ant := range q {
+				going[ant] = true
+				loc[ant] = 1
 				*done++
+				ms = append(ms, move{ant: ant + 1, room: next})
 			}
+			wait[i] = nil
+			continue
+		}
+		ant := q[0]
+		if busy[next] == 0 {
+			going[ant] = true
+			loc[ant] = 1
+			busy[next] = ant + 1
 			wait[i] = q[1:]
 			ms = append(ms, move{ant: ant + 1, room: next})
 		}
@@ -65,12 +72,31 @@ func startAnts(g *Graph, paths [][]*Room, wait [][]int, going []bool, loc []int,
 	return ms
 }
 
+// expandPaths puts a hidden room for every extra turn a tunnel costs, so
+// an ant walking a slow tunnel holds it the way it would hold a room.
+func expandPaths(paths [][]*Room) [][]*Room {
+	out := make([][]*Room, len(paths))
+	for i, path := range paths {
+		out[i] = []*Room{path[0]}
+		for j := 1; j < len(path); j++ {
+			for c := stepCost(path[j-1], path[j]); c > 1; c-- {
+				out[i] = append(out[i], &Room{})
+			}
+			out[i] = append(out[i], path[j])
+		}
+	}
+	return out
+}
+
 // formatMoves turns a slice of moves into output text.
+// Steps into hidden tunnel rooms are left out.
 func formatMoves(ms []move) string {
 	sort.Slice(ms, func(i, j int) bool { return ms[i].ant < ms[j].ant })
-	line := make([]string, len(ms))
-	for i, m := range ms {
-		line[i] = fmt.Sprintf("L%d-%s", m.ant, m.room.Name)
+	var line []string
+	for _, m := range ms {
+		if m.room.Name != "" {
+			line = append(line, fmt.Sprintf("L%d-%s", m.ant, m.room.Name))
+		}
 	}
 	return strings.Join(line, " ")
 }
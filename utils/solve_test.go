@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exampleTurns is how many turns each bundled map must take. The counts
+// match the known optimum for the standard lem-in examples; example08 is
+// only the current result, kept to catch a change that makes it worse.
+var exampleTurns = []struct {
+	file  string
+	turns int
+}{
+	{"example00.txt", 6},
+	{"example01.txt", 8},
+	{"example02.txt", 1},
+	{"example03.txt", 50002},
+	{"example04.txt", 6},
+	{"example05.txt", 8},
+	{"example06.txt", 52},
+	{"example07.txt", 502},
+	{"example08.txt", 576},
+}
+
+// loadExample parses one of the maps in the examples directory.
+func loadExample(t testing.TB, name string) *Graph {
+	t.Helper()
+	g, _, err := ParseInput(filepath.Join("..", "examples", name))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return g
+}
+
+func TestExampleTurns(t *testing.T) {
+	for _, tc := range exampleTurns {
+		t.Run(tc.file, func(t *testing.T) {
+			g := loadExample(t, tc.file)
+			if _, got := SimulateMulti(g, FindPaths(g)); got != tc.turns {
+				t.Errorf("got %d turns, want %d", got, tc.turns)
+			}
+		})
+	}
+}
+
+// chain returns a path of n steps through new rooms.
+func chain(n int) []*Room {
+	p := []*Room{{}}
+	for i := 0; i < n; i++ {
+		r := &Room{}
+		p[i].Links = append(p[i].Links, r)
+		p = append(p, r)
+	}
+	return p
+}
+
+func TestPathLoads(t *testing.T) {
+	// With paths of 2 and 4 steps, 5 ants finish in 5 turns when the long
+	// path takes one of them, instead of 6 turns on the short path alone.
+	got := PathLoads([][]*Room{chain(2), chain(4)}, 5)
+	if len(got) != 2 || got[0] != 4 || got[1] != 1 {
+		t.Fatalf("PathLoads = %v, want [4 1]", got)
+	}
+}
+
+// TestExampleMoves compares the exact moves printed for two small maps.
+func TestExampleMoves(t *testing.T) {
+	want := map[string][]string{
+		"example00.txt": {
+			"L1-2",
+			"L1-3 L2-2",
+			"L1-1 L2-3 L3-2",
+			"L2-1 L3-3 L4-2",
+			"L3-1 L4-3",
+			"L4-1",
+		},
+		"example01.txt": {
+			"L1-0 L2-h L3-t",
+			"L1-o L2-A L3-E L4-0 L5-h L6-t",
+			"L1-n L2-c L3-a L4-o L5-A L6-E L7-0 L8-h",
+			"L1-e L2-k L3-m L4-n L5-c L6-a L7-o L8-A L9-0 L10-h",
+			"L1-end L2-end L3-end L4-e L5-k L6-m L7-n L8-c L9-o L10-A",
+			"L4-end L5-end L6-end L7-e L8-k L9-n L10-c",
+			"L7-end L8-end L9-e L10-k",
+			"L9-end L10-end",
+		},
+	}
+	for file, moves := range want {
+		g := loadExample(t, file)
+		got, _ := SimulateMulti(g, FindPaths(g))
+		if strings.Join(got, "\n") != strings.Join(moves, "\n") {
+			t.Errorf("%s: got\n%s\nwant\n%s", file, strings.Join(got, "\n"), strings.Join(moves, "\n"))
+		}
+	}
+}
+
+// TestSolveIgnoresLinkOrder solves maps with their link lines shuffled and
+// written either way round; the moves must not change.
+func TestSolveIgnoresLinkOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, file := range []string{"example01.txt", "example05.txt", "example08.txt"} {
+		_, lines, err := ParseInput(filepath.Join("..", "examples", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := solveText(loadExample(t, file))
+		var at []int // where the link lines are
+		for i, l := range lines {
+			if _, ok := splitLink(l, false); ok && !strings.HasPrefix(l, "#") {
+				at = append(at, i)
+			}
+		}
+		for run := 0; run < 10; run++ {
+			shuffled := append([]string{}, lines...)
+			for k, i := range rng.Perm(len(at)) {
+				l := lines[at[i]]
+				if from, to, _ := strings.Cut(l, "-"); rng.Intn(2) == 0 {
+					l = to + "-" + from
+				}
+				shuffled[at[k]] = l
+			}
+			g := parseMap(t, strings.Join(shuffled, "\n"))
+			if got := solveText(g); got != want {
+				t.Fatalf("%s: shuffle %d gave different moves", file, run)
+			}
+		}
+	}
+}
+
+func TestNoAnts(t *testing.T) {
+	g := loadExample(t, "noants.txt")
+	if _, turns := SimulateMulti(g, FindPaths(g)); turns != 0 {
+		t.Errorf("got %d turns, want none", turns)
+	}
+	if got := g.MinTurns(0); got != 0 {
+		t.Errorf("MinTurns(0) = %d, want 0", got)
+	}
+}
+
+// TestDirectLink checks every ant crosses a start-end tunnel in turn one,
+// even when other paths exist.
+func TestDirectLink(t *testing.T) {
+	g := loadExample(t, "example02.txt")
+	moves, _ := SimulateMulti(g, FindPaths(g))
+	if len(moves) != 1 {
+		t.Fatalf("got %d turns, want 1", len(moves))
+	}
+	if n := len(strings.Fields(moves[0])); n != g.Ants {
+		t.Errorf("got %d moves in turn one, want %d", n, g.Ants)
+	}
+}
+
+func BenchmarkFindPaths(b *testing.B) {
+	for _, tc := range exampleTurns {
+		g := loadExample(b, tc.file)
+		b.Run(tc.file, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				FindPaths(g)
+			}
+		})
+	}
+}
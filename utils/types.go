@@ -3,12 +3,48 @@ package utils
 const (
 	MaxPaths = 100
 	MaxAnts  = 50000
+
+	// Default size caps checked while parsing, so a huge map is rejected
+	// before it is built
+	MaxRooms = 100000
+	MaxLinks = 1000000
 )
 
+// Limits caps the size of a map being parsed. A zero field means the
+// default MaxRooms or MaxLinks.
+type Limits struct {
+	Rooms int
+	Links int
+}
+
+// rooms returns the room cap to use.
+func (l Limits) rooms() int {
+	if l.Rooms == 0 {
+		return MaxRooms
+	}
+	return l.Rooms
+}
+
+// links returns the link cap to use.
+func (l Limits) links() int {
+	if l.Links == 0 {
+		return MaxLinks
+	}
+	return l.Links
+}
+
+// ParseOptions change how a map is read. The zero value reads a plain
+// lem-in map with the default size caps.
+type ParseOptions struct {
+	Limits
+	Weighted bool // read a link ending in ":n" as a tunnel taking n turns
+}
+
 type Room struct {
 	Name  string
 	X, Y  int
 	Links []*Room
+	Costs map[*Room]int // turns needed to reach a linked room, when not 1
 }
 
 type Graph struct {